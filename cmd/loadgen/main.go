@@ -3,8 +3,11 @@
 // 주요 기능:
 //   - 동시 요청 수 (concurrency) 조절
 //   - keep-alive on/off 토글
-//   - latency 통계 (평균, p50, p95, p99)
-//   - RPS (requests per second) 측정
+//   - latency 통계 (평균, p50, p95, p99) - 로그 버킷 히스토그램 기반
+//   - RPS (requests per second) 측정 및 상한 설정 (-rps)
+//   - 초기 구간을 통계에서 제외하는 warmup 윈도우 (-warmup)
+//   - net/http.Client 대신 고정 크기 net.Conn 풀을 직접 구동하는 raw 모드 (-mode=raw)
+//   - POST/PUT/DELETE 메서드, 요청 바디, URL 목록 round-robin (-method, -body, -urls)
 //
 // 실험 목적:
 //   - keep-alive on/off에 따른 성능 차이 측정
@@ -26,14 +29,15 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"sort"
 	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/ahwlsqja/go-http-lab/internal/histogram"
 	"github.com/ahwlsqja/go-http-lab/internal/logger"
 	"github.com/rs/zerolog"
+	"golang.org/x/net/http2"
 )
 
 // Config holds loadgen configuration.
@@ -44,6 +48,17 @@ type Config struct {
 	KeepAlive   bool          // keep-alive 활성화 여부
 	Timeout     time.Duration // 요청 타임아웃
 	Debug       bool          // 디버그 로깅
+
+	RPS    int           // 초당 요청 수 상한 (0 = 제한 없음, open-loop)
+	Warmup time.Duration // 통계에서 제외할 초기 구간
+
+	Mode  string // "http" (기본, net/http.Client) 또는 "raw" (rawpool 커넥션 풀)
+	HTTP2 bool   // TLS ALPN으로 h2를 negotiate (서버의 -http2 모드와 짝을 맞출 때 사용)
+
+	Method      string // HTTP 메서드 (GET, POST, PUT, DELETE, ...)
+	Body        string // 요청 바디: 인라인 문자열 또는 "@file" 경로
+	ContentType string // Content-Type 헤더
+	URLs        string // "@file.txt" - 워커들이 round-robin으로 순회할 URL 목록
 }
 
 // Stats holds request statistics.
@@ -53,9 +68,10 @@ type Stats struct {
 	errorCount     atomic.Int64
 	totalLatencyNs atomic.Int64 // 나노초 단위 총 latency
 
-	// latency 분포 (뮤텍스로 보호)
-	latencies []time.Duration
-	mu        sync.Mutex
+	// latency 분포 - 정렬된 슬라이스 대신 로그 버킷 히스토그램에 누적한다.
+	// (장시간 테스트에서 raw 샘플을 모두 들고 있으면 메모리를 소진하고
+	// cold-start 샘플이 섞여 p95/p99가 왜곡된다)
+	hist histogram.Histogram
 }
 
 var (
@@ -72,8 +88,20 @@ func main() {
 		Int("concurrency", cfg.Concurrency).
 		Dur("duration", cfg.Duration).
 		Bool("keep_alive", cfg.KeepAlive).
+		Int("rps", cfg.RPS).
+		Dur("warmup", cfg.Warmup).
+		Str("method", cfg.Method).
 		Msg("starting load generator")
 
+	// 요청 팩토리 생성 - 단일 타겟 또는 -urls round-robin, 매 호출마다
+	// 새 *http.Request를 내어주므로 재시도/keep-alive 재사용 시에도
+	// 바디가 항상 읽히지 않은 상태로 시작한다.
+	factory, err := buildRequestFactory(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+
 	// HTTP 클라이언트 생성
 	client := createHTTPClient(cfg)
 
@@ -94,17 +122,38 @@ func main() {
 		close(ctx)
 	}()
 
+	// -rps가 설정된 경우, 티커 하나가 버퍼 채널에 토큰을 채우고
+	// 워커들은 요청 전에 거기서 토큰을 받아간다. concurrency만큼 버스트를
+	// 흡수하면서도 장기 평균은 N req/sec로 유지된다. 0이면 open-loop.
+	var tokens chan struct{}
+	if cfg.RPS > 0 {
+		tokens = make(chan struct{}, cfg.Concurrency)
+		go rateLimiter(ctx, tokens, cfg.RPS)
+	}
+
 	// 시작 시간 기록
 	startTime := time.Now()
 
 	// 워커 시작
+	// -mode=raw는 net/http.Client를 거치지 않고 rawpool 커넥션 풀을 직접
+	// 구동한다 (Transport 오버헤드와의 비교용).
 	var wg sync.WaitGroup
-	for i := 0; i < cfg.Concurrency; i++ {
+	if cfg.Mode == "raw" {
 		wg.Add(1)
-		go func(workerID int) {
+		go func() {
 			defer wg.Done()
-			worker(ctx, client, cfg.Target, workerID)
-		}(i)
+			if err := runRawMode(ctx, cfg, factory, tokens, startTime); err != nil {
+				log.Fatal().Err(err).Msg("raw mode failed to start")
+			}
+		}()
+	} else {
+		for i := 0; i < cfg.Concurrency; i++ {
+			wg.Add(1)
+			go func(workerID int) {
+				defer wg.Done()
+				worker(ctx, client, factory, workerID, tokens, startTime, cfg.Warmup)
+			}(i)
+		}
 	}
 
 	// 진행 상황 리포터
@@ -131,11 +180,41 @@ func parseFlags() Config {
 	flag.BoolVar(&cfg.KeepAlive, "keep-alive", true, "enable HTTP keep-alive")
 	flag.DurationVar(&cfg.Timeout, "timeout", 10*time.Second, "request timeout")
 	flag.BoolVar(&cfg.Debug, "debug", false, "enable debug logging")
+	flag.IntVar(&cfg.RPS, "rps", 0, "cap requests per second (0 = unlimited, open-loop)")
+	flag.DurationVar(&cfg.Warmup, "warmup", 0, "exclude an initial warmup window from reported stats")
+	flag.StringVar(&cfg.Mode, "mode", "http", "request mode: http (net/http.Client) or raw (persistent net.Conn pool)")
+	flag.BoolVar(&cfg.HTTP2, "http2", false, "negotiate HTTP/2 via TLS ALPN")
+	flag.StringVar(&cfg.Method, "method", http.MethodGet, "HTTP method (GET, POST, PUT, DELETE, ...)")
+	flag.StringVar(&cfg.Body, "body", "", "request body: inline string or @file path")
+	flag.StringVar(&cfg.ContentType, "content-type", "", "Content-Type header for the request body")
+	flag.StringVar(&cfg.URLs, "urls", "", "@file.txt of URLs to round-robin through (overrides -target)")
 
 	flag.Parse()
 	return cfg
 }
 
+// rateLimiter feeds the token channel at a steady N per second so that
+// worker bursts up to cap(tokens) are absorbed but the long-run average
+// request rate stays at rps.
+func rateLimiter(ctx <-chan struct{}, tokens chan<- struct{}, rps int) {
+	interval := time.Second / time.Duration(rps)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx:
+			return
+		case <-ticker.C:
+			select {
+			case tokens <- struct{}{}:
+			default:
+				// 채널이 가득 찼으면 토큰 버림 (워커가 못 따라가는 중)
+			}
+		}
+	}
+}
+
 // createHTTPClient creates an HTTP client with the given configuration.
 func createHTTPClient(cfg Config) *http.Client {
 	transport := &http.Transport{
@@ -148,6 +227,14 @@ func createHTTPClient(cfg Config) *http.Client {
 		IdleConnTimeout:     90 * time.Second,
 	}
 
+	if cfg.HTTP2 {
+		// ALPN으로 h2를 negotiate하도록 Transport를 h2용으로 재구성한다.
+		// 서버의 -http2 모드와 짝을 맞춰 keep-alive/멀티플렉싱을 비교할 때 쓴다.
+		if err := http2.ConfigureTransport(transport); err != nil {
+			log.Fatal().Err(err).Msg("failed to configure http2 transport")
+		}
+	}
+
 	return &http.Client{
 		Transport: transport,
 		Timeout:   cfg.Timeout,
@@ -155,7 +242,9 @@ func createHTTPClient(cfg Config) *http.Client {
 }
 
 // worker는 지속적으로 요청을 보내는 워커 goroutine이다.
-func worker(ctx <-chan struct{}, client *http.Client, target string, workerID int) {
+// tokens가 nil이 아니면 요청 전에 토큰을 받아갈 때까지 대기한다 (-rps 제한).
+// warmup 구간 동안에는 요청은 수행하되 통계에는 반영하지 않는다.
+func worker(ctx <-chan struct{}, client *http.Client, factory RequestFactory, workerID int, tokens <-chan struct{}, startTime time.Time, warmup time.Duration) {
 	for {
 		select {
 		case <-ctx:
@@ -163,10 +252,22 @@ func worker(ctx <-chan struct{}, client *http.Client, target string, workerID in
 		default:
 		}
 
+		if tokens != nil {
+			select {
+			case <-ctx:
+				return
+			case <-tokens:
+			}
+		}
+
 		start := time.Now()
-		err := doRequest(client, target)
+		err := doRequest(client, factory)
 		latency := time.Since(start)
 
+		if time.Since(startTime) < warmup {
+			continue
+		}
+
 		stats.totalRequests.Add(1)
 		stats.totalLatencyNs.Add(int64(latency))
 
@@ -175,18 +276,19 @@ func worker(ctx <-chan struct{}, client *http.Client, target string, workerID in
 			log.Debug().Err(err).Int("worker", workerID).Msg("request failed")
 		} else {
 			stats.successCount.Add(1)
-
-			// latency 기록 (통계용)
-			stats.mu.Lock()
-			stats.latencies = append(stats.latencies, latency)
-			stats.mu.Unlock()
+			stats.hist.Record(latency)
 		}
 	}
 }
 
 // doRequest는 단일 HTTP 요청을 수행한다.
-func doRequest(client *http.Client, target string) error {
-	resp, err := client.Get(target)
+func doRequest(client *http.Client, factory RequestFactory) error {
+	req, err := factory.NewRequest()
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -250,30 +352,13 @@ func printResults(elapsed time.Duration, cfg Config) {
 	// RPS 계산
 	rps := float64(total) / elapsed.Seconds()
 
-	// Latency 통계 계산
-	stats.mu.Lock()
-	latencies := make([]time.Duration, len(stats.latencies))
-	copy(latencies, stats.latencies)
-	stats.mu.Unlock()
-
+	// Latency 통계 계산 - 히스토그램의 CDF를 순회해서 percentile을 구한다.
 	var avgLatency, p50, p95, p99 time.Duration
-	if len(latencies) > 0 {
-		// 정렬
-		sort.Slice(latencies, func(i, j int) bool {
-			return latencies[i] < latencies[j]
-		})
-
-		// 평균
-		var sum time.Duration
-		for _, l := range latencies {
-			sum += l
-		}
-		avgLatency = sum / time.Duration(len(latencies))
-
-		// 백분위수
-		p50 = percentile(latencies, 50)
-		p95 = percentile(latencies, 95)
-		p99 = percentile(latencies, 99)
+	if stats.hist.Count() > 0 {
+		avgLatency = stats.hist.Mean()
+		p50 = stats.hist.Percentile(50)
+		p95 = stats.hist.Percentile(95)
+		p99 = stats.hist.Percentile(99)
 	}
 
 	// 결과 출력
@@ -285,6 +370,12 @@ func printResults(elapsed time.Duration, cfg Config) {
 	fmt.Printf("Concurrency:  %d\n", cfg.Concurrency)
 	fmt.Printf("Duration:     %s\n", elapsed.Round(time.Millisecond))
 	fmt.Printf("Keep-Alive:   %v\n", cfg.KeepAlive)
+	if cfg.RPS > 0 {
+		fmt.Printf("RPS Cap:      %d\n", cfg.RPS)
+	}
+	if cfg.Warmup > 0 {
+		fmt.Printf("Warmup:       %s (excluded from stats)\n", cfg.Warmup)
+	}
 	fmt.Println("------------------------------------------------------------")
 	fmt.Printf("Total Requests: %d\n", total)
 	fmt.Printf("Successful:     %d (%.1f%%)\n", success, float64(success)/float64(total)*100)
@@ -299,12 +390,3 @@ func printResults(elapsed time.Duration, cfg Config) {
 	fmt.Printf("  P99:          %s\n", p99.Round(time.Microsecond))
 	fmt.Println("============================================================")
 }
-
-// percentile calculates the p-th percentile of sorted durations.
-func percentile(sorted []time.Duration, p int) time.Duration {
-	if len(sorted) == 0 {
-		return 0
-	}
-	idx := (len(sorted) - 1) * p / 100
-	return sorted[idx]
-}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ahwlsqja/go-http-lab/internal/rawpool"
+)
+
+// runRawMode drives the load test through a fixed-size pool of persistent
+// net.Conn objects instead of net/http.Client, to measure the overhead
+// Transport's per-request bookkeeping adds on top of a bare connection.
+func runRawMode(ctx <-chan struct{}, cfg Config, factory RequestFactory, tokens <-chan struct{}, startTime time.Time) error {
+	addr, err := factory.Addr()
+	if err != nil {
+		return err
+	}
+
+	dial := func() (net.Conn, error) {
+		return net.DialTimeout("tcp", addr, cfg.Timeout)
+	}
+
+	pool, err := rawpool.NewConnectionManager(cfg.Concurrency, dial)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			rawWorker(ctx, pool, factory, cfg, workerID, tokens, startTime)
+		}(i)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// rawWorker is the raw-pool counterpart of worker: it checks a connection
+// out of the pool, serializes a fresh request from factory onto it, and
+// returns the connection (or a freshly dialed replacement on error).
+func rawWorker(ctx <-chan struct{}, pool *rawpool.ConnectionManager, factory RequestFactory, cfg Config, workerID int, tokens <-chan struct{}, startTime time.Time) {
+	for {
+		select {
+		case <-ctx:
+			return
+		default:
+		}
+
+		if tokens != nil {
+			select {
+			case <-ctx:
+				return
+			case <-tokens:
+			}
+		}
+
+		req, err := factory.NewRequest()
+		if err != nil {
+			log.Fatal().Err(err).Int("worker", workerID).Msg("failed to build raw request")
+		}
+		req.Close = false // keep-alive: 연결은 풀이 재사용한다
+
+		conn := pool.Get()
+
+		start := time.Now()
+		resp, err := rawpool.Do(conn, req, cfg.Timeout)
+		if err == nil {
+			_, err = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		latency := time.Since(start)
+
+		if err != nil {
+			pool.Replace(conn, ctx)
+		} else {
+			pool.Put(conn)
+		}
+
+		if time.Since(startTime) < cfg.Warmup {
+			continue
+		}
+
+		stats.totalRequests.Add(1)
+		stats.totalLatencyNs.Add(int64(latency))
+
+		if err != nil {
+			stats.errorCount.Add(1)
+			log.Debug().Err(err).Int("worker", workerID).Msg("raw request failed")
+		} else {
+			stats.successCount.Add(1)
+			stats.hist.Record(latency)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// RequestFactory yields a fresh *http.Request for each call. Returning a
+// new request (with its own body reader) every time means retries and
+// keep-alive connection reuse don't fight over an already-consumed body.
+type RequestFactory interface {
+	NewRequest() (*http.Request, error)
+
+	// Addr returns the host:port -mode=raw dials to. With -urls, every URL
+	// is expected to share the same host - raw mode pre-dials a fixed-size
+	// pool against one address, unlike the http.Client path.
+	Addr() (string, error)
+}
+
+// staticRequestFactory issues every request against a single URL.
+type staticRequestFactory struct {
+	method      string
+	url         string
+	body        []byte
+	contentType string
+}
+
+func (f *staticRequestFactory) NewRequest() (*http.Request, error) {
+	return newRequest(f.method, f.url, f.body, f.contentType)
+}
+
+func (f *staticRequestFactory) Addr() (string, error) {
+	return addrOf(f.url)
+}
+
+// urlListRequestFactory round-robins through a fixed list of URLs, sharing
+// the same method/body/content-type across all of them.
+type urlListRequestFactory struct {
+	method      string
+	urls        []string
+	body        []byte
+	contentType string
+	next        atomic.Uint64
+}
+
+func (f *urlListRequestFactory) NewRequest() (*http.Request, error) {
+	i := f.next.Add(1) - 1
+	url := f.urls[i%uint64(len(f.urls))]
+	return newRequest(f.method, url, f.body, f.contentType)
+}
+
+func (f *urlListRequestFactory) Addr() (string, error) {
+	return addrOf(f.urls[0])
+}
+
+func addrOf(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	if u.Scheme == "https" {
+		return u.Host + ":443", nil
+	}
+	return u.Host + ":80", nil
+}
+
+func newRequest(method, url string, body []byte, contentType string) (*http.Request, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, url, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+// buildRequestFactory turns the flag-level Config into a RequestFactory,
+// loading -body and -urls from disk when given an @file argument.
+func buildRequestFactory(cfg Config) (RequestFactory, error) {
+	body, err := loadMaybeFile(cfg.Body)
+	if err != nil {
+		return nil, fmt.Errorf("loading -body: %w", err)
+	}
+
+	if cfg.URLs == "" {
+		return &staticRequestFactory{
+			method:      cfg.Method,
+			url:         cfg.Target,
+			body:        body,
+			contentType: cfg.ContentType,
+		}, nil
+	}
+
+	urls, err := loadURLList(cfg.URLs)
+	if err != nil {
+		return nil, fmt.Errorf("loading -urls: %w", err)
+	}
+
+	return &urlListRequestFactory{
+		method:      cfg.Method,
+		urls:        urls,
+		body:        body,
+		contentType: cfg.ContentType,
+	}, nil
+}
+
+// loadMaybeFile returns s as-is, unless it starts with "@" in which case
+// the rest is treated as a file path whose contents are returned instead.
+// An empty s yields a nil body (no -body flag given).
+func loadMaybeFile(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(s, "@") {
+		return []byte(s), nil
+	}
+	return os.ReadFile(strings.TrimPrefix(s, "@"))
+}
+
+// loadURLList reads one URL per line from an "@file.txt" argument.
+func loadURLList(s string) ([]string, error) {
+	path := strings.TrimPrefix(s, "@")
+	if path == s {
+		return nil, fmt.Errorf("-urls must be given as @file.txt, got %q", s)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var urls []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("%s contains no URLs", path)
+	}
+	return urls, nil
+}
@@ -6,6 +6,9 @@
 //   - ConnState 훅을 통한 연결 상태 추적
 //   - 요청별 구조화 로깅 (request_id, latency, bytes)
 //   - pprof 엔드포인트 (별도 포트)
+//   - Prometheus text exposition 형식의 /metrics 엔드포인트
+//   - -max-conns로 동시 연결 수를 제한하는 LimitListener
+//   - -tls-cert/-tls-key/-http2로 TLS + HTTP/2 서빙, 프로토콜별 연결 상태 추적
 //
 // 사용 예:
 //
@@ -18,10 +21,12 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	_ "net/http/pprof" // pprof 핸들러 자동 등록
 	"os"
@@ -34,7 +39,9 @@ import (
 
 	"github.com/ahwlsqja/go-http-lab/internal/logger"
 	"github.com/ahwlsqja/go-http-lab/internal/metrics"
+	"github.com/ahwlsqja/go-http-lab/internal/netx"
 	"github.com/rs/zerolog"
+	"golang.org/x/net/http2"
 )
 
 // Config holds server configuration from flags.
@@ -51,14 +58,20 @@ type Config struct {
 
 	// 서버 동작 옵션
 	MaxHeaderBytes  int
+	MaxConns        int           // 동시 오픈 연결 수 상한 (0 = 무제한)
 	ShutdownTimeout time.Duration // graceful shutdown 대기 시간
 	Debug           bool
+
+	TLSCert string // TLS 인증서 경로 (설정되면 TLS 모드로 전환)
+	TLSKey  string // TLS 개인키 경로
+	HTTP2   bool   // TLS 위에서 h2 지원 (TLSCert/TLSKey 필요)
 }
 
 // 전역 상태 (실험용)
 var (
 	requestCounter atomic.Uint64
 	connCounter    *metrics.ConnStateCounter
+	reqMetrics     *metrics.RequestMetrics
 	log            zerolog.Logger
 )
 
@@ -79,6 +92,7 @@ func main() {
 
 	// 연결 상태 카운터 초기화
 	connCounter = metrics.NewConnStateCounter(log)
+	reqMetrics = metrics.NewRequestMetrics()
 
 	// pprof 서버 (별도 goroutine)
 	go runPprofServer(cfg.PprofPort)
@@ -124,19 +138,84 @@ func main() {
 		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 		defer cancel()
 
+		// drain 진행 상황을 1초마다 로깅 - active/idle이 줄어드는 걸
+		// 운영자가 눈으로 볼 수 있게 한다.
+		drainDone := make(chan struct{})
+		go func() {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-drainDone:
+					return
+				case <-ticker.C:
+					snap := connCounter.GetSnapshot()
+					log.Info().
+						Int64("active", snap.Active).
+						Int64("idle", snap.Idle).
+						Msg("draining connections")
+				}
+			}
+		}()
+
 		shutdownStart := time.Now()
-		if err := server.Shutdown(ctx); err != nil {
-			log.Error().Err(err).Dur("elapsed", time.Since(shutdownStart)).Msg("shutdown error (timeout?)")
+		err := server.Shutdown(ctx)
+		close(drainDone)
+
+		if err != nil {
+			// ShutdownTimeout이 지났는데도 active 연결이 남아있다는 뜻.
+			// 누가 서버를 붙잡고 있었는지 강제로 닫아서 정리한다.
+			remaining := connCounter.ActiveConns()
+			log.Error().
+				Err(err).
+				Int("remaining_conns", len(remaining)).
+				Dur("elapsed", time.Since(shutdownStart)).
+				Msg("shutdown timeout exceeded, force closing remaining connections")
+			for _, c := range remaining {
+				c.Close()
+			}
 		} else {
 			log.Info().Dur("elapsed", time.Since(shutdownStart)).Msg("shutdown completed gracefully")
 		}
 		close(done)
 	}()
 
+	// 리스너 생성 - max-conns가 설정되면 동시 오픈 연결 수를 제한해서
+	// slowloris류 실험에서 무제한 goroutine 증가 대신 backpressure를 보여준다.
+	ln, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to listen")
+	}
+	if cfg.MaxConns > 0 {
+		ln = netx.LimitListener(ln, cfg.MaxConns)
+	}
+
+	// TLS + HTTP/2 모드 - 같은 핸들러 셋을 TLS/h2로도 서빙해서 h1과
+	// keep-alive/멀티플렉싱 동작을 같은 랩에서 비교할 수 있게 한다.
+	if cfg.TLSCert != "" && cfg.TLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to load TLS certificate")
+		}
+		server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+
+		if cfg.HTTP2 {
+			// net/http의 ConnState 훅은 StateActive 시점에 기반 conn을
+			// *tls.Conn으로 넘겨주므로, ConnStateCounter가 ALPN negotiate된
+			// 프로토콜을 엿볼 수 있다 (internal/metrics 참조).
+			if err := http2.ConfigureServer(server, nil); err != nil {
+				log.Fatal().Err(err).Msg("failed to configure http2")
+			}
+		}
+
+		ln = tls.NewListener(ln, server.TLSConfig)
+		log.Info().Bool("http2", cfg.HTTP2).Msg("serving over TLS")
+	}
+
 	// 서버 시작
 	log.Info().Msgf("listening on :%d", cfg.Port)
 	// ListenAndServer가 돌면서 Accept 블록킹 -> 즉 연결 마다 고루틴 만들어서 계쏙 도는 겅미 그러다가 ListenAndServe가 ErrServerClosed 반환하면  <- done 으로감
-	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+	if err := server.Serve(ln); err != http.ErrServerClosed {
 		log.Fatal().Err(err).Msg("server error")
 	}
 
@@ -158,6 +237,10 @@ func parseFlags() Config {
 	flag.DurationVar(&cfg.IdleTimeout, "idle-timeout", 60*time.Second, "http.Server.IdleTimeout (0 = no timeout)")
 
 	flag.IntVar(&cfg.MaxHeaderBytes, "max-header-bytes", 1<<20, "http.Server.MaxHeaderBytes")
+	flag.IntVar(&cfg.MaxConns, "max-conns", 0, "max simultaneous open connections (0 = unlimited)")
+	flag.StringVar(&cfg.TLSCert, "tls-cert", "", "TLS certificate path (enables TLS mode)")
+	flag.StringVar(&cfg.TLSKey, "tls-key", "", "TLS private key path (enables TLS mode)")
+	flag.BoolVar(&cfg.HTTP2, "http2", false, "enable HTTP/2 over TLS (requires -tls-cert/-tls-key)")
 	flag.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", 30*time.Second, "graceful shutdown timeout")
 	flag.BoolVar(&cfg.Debug, "debug", false, "enable debug logging")
 
@@ -183,6 +266,7 @@ func registerHandlers(mux *http.ServeMux) {
 	mux.HandleFunc("/echo", withLogging(echoHandler))
 	mux.HandleFunc("/readbody", withLogging(readBodyHandler))
 	mux.HandleFunc("/stats", withLogging(statsHandler))
+	mux.HandleFunc("/metrics", metricsHandler) // Prometheus에서 직접 scrape; 로깅 미들웨어로 감싸지 않는다
 }
 
 // withLogging은 요청 로깅 미들웨어다.
@@ -195,6 +279,9 @@ func withLogging(handler http.HandlerFunc) http.HandlerFunc {
 		// 응답 래퍼로 바이트 수 추적
 		rw := &responseWriter{ResponseWriter: w}
 
+		// 라우트별 요청 수 / in-flight / 바이트 / latency 메트릭
+		done := reqMetrics.Begin(r.URL.Path)
+
 		log.Debug().
 			Uint64("request_id", reqID).
 			Str("method", r.Method).
@@ -204,13 +291,16 @@ func withLogging(handler http.HandlerFunc) http.HandlerFunc {
 
 		handler(rw, r)
 
+		latency := time.Since(start)
+		done(rw.bytesWritten, latency)
+
 		log.Info().
 			Uint64("request_id", reqID).
 			Str("method", r.Method).
 			Str("path", r.URL.Path).
 			Int("status", rw.status).
 			Int("bytes_written", rw.bytesWritten).
-			Dur("latency", time.Since(start)).
+			Dur("latency", latency).
 			Msg("request completed")
 	}
 }
@@ -354,3 +444,12 @@ func statsHandler(w http.ResponseWriter, r *http.Request) {
 		},
 	})
 }
+
+// metricsHandler exposes connection-state and per-route request metrics in
+// Prometheus text exposition format, so a Prometheus server can scrape the
+// lab directly instead of polling /stats by hand.
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	connCounter.WriteProm(w)
+	reqMetrics.WriteProm(w)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ahwlsqja/go-http-lab/pkg/slowloris"
+)
+
+// reportProbeResult prints the probe outcome either as a human-readable
+// summary or, with -output=json, as a single JSON line for CI consumption.
+func reportProbeResult(result slowloris.ProbeResult, output string) error {
+	if output == "json" {
+		return json.NewEncoder(os.Stdout).Encode(result)
+	}
+
+	fmt.Println()
+	fmt.Println("============================================================")
+	fmt.Println("SLOWLORIS PROBE RESULT (non-destructive)")
+	fmt.Println("============================================================")
+	fmt.Printf("Target:       %s\n", result.Target)
+	fmt.Printf("Close gap:    %s (threshold %s)\n", result.CloseGap.Round(time.Millisecond), result.Threshold)
+	if result.ErrorA != "" {
+		fmt.Printf("Connection A: %s\n", result.ErrorA)
+	}
+	if result.ErrorB != "" {
+		fmt.Printf("Connection B: %s\n", result.ErrorB)
+	}
+	if result.Vulnerable {
+		fmt.Println("Verdict:      LIKELY VULNERABLE to slowloris")
+	} else {
+		fmt.Println("Verdict:      PROTECTED")
+	}
+	fmt.Println("============================================================")
+
+	return nil
+}
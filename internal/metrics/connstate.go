@@ -12,6 +12,9 @@
 package metrics
 
 import (
+	"crypto/tls"
+	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"sync"
@@ -20,20 +23,50 @@ import (
 	"github.com/rs/zerolog"
 )
 
-// ConnStateCounter tracks the count of connections in each state.
-type ConnStateCounter struct {
-	// 각 상태별 현재 연결 수 (atomic으로 thread-safe)
+// protoUnknown is the bucket used for plain TCP connections and for TLS
+// connections before ALPN has negotiated a protocol.
+const protoUnknown = "http/1.1"
+
+// connInfo is what we need to remember per tracked connection: its current
+// http.ConnState, and the ALPN protocol negotiated over it (if TLS).
+type connInfo struct {
+	state http.ConnState
+	proto string
+}
+
+// protoCounters holds per-state counters for a single protocol bucket.
+type protoCounters struct {
 	stateNew      atomic.Int64
 	stateActive   atomic.Int64
 	stateIdle     atomic.Int64
 	stateHijacked atomic.Int64
+}
+
+func (p *protoCounters) add(state http.ConnState, delta int64) {
+	switch state {
+	case http.StateNew:
+		p.stateNew.Add(delta)
+	case http.StateActive:
+		p.stateActive.Add(delta)
+	case http.StateIdle:
+		p.stateIdle.Add(delta)
+	case http.StateHijacked:
+		p.stateHijacked.Add(delta)
+	}
+}
+
+// ConnStateCounter tracks the count of connections in each state, broken
+// down by ALPN-negotiated protocol (http/1.1 vs h2).
+type ConnStateCounter struct {
+	h1 protoCounters
+	h2 protoCounters
 
 	// 누적 카운터 (총 연결 수 추적)
 	totalAccepted atomic.Int64
 	totalClosed   atomic.Int64
 
-	// 연결별 현재 상태 추적 (상태 전이 시 이전 상태 감소 위해 필요)
-	connStates map[net.Conn]http.ConnState
+	// 연결별 현재 상태 + 프로토콜 추적 (상태 전이 시 이전 값 감소 위해 필요)
+	connStates map[net.Conn]connInfo
 	mu         sync.RWMutex
 
 	logger zerolog.Logger
@@ -42,7 +75,7 @@ type ConnStateCounter struct {
 // NewConnStateCounter creates a new connection state counter.
 func NewConnStateCounter(logger zerolog.Logger) *ConnStateCounter {
 	return &ConnStateCounter{
-		connStates: make(map[net.Conn]http.ConnState),
+		connStates: make(map[net.Conn]connInfo),
 		logger:     logger,
 	}
 }
@@ -55,33 +88,38 @@ func NewConnStateCounter(logger zerolog.Logger) *ConnStateCounter {
 //   - StateActive: 첫 바이트 읽기 시작 시
 //   - StateIdle:   응답 완료 후 keep-alive 대기 진입 시
 //   - StateClosed: 연결 종료 시 (정상 종료, 타임아웃, 에러 등)
+//
+// conn이 *tls.Conn이면 StateActive 전이 시점에 ALPN으로 negotiate된
+// 프로토콜(h2 / http/1.1)을 엿봐서 연결의 proto 버킷을 갱신한다. 핸드셰이크가
+// StateNew 시점엔 아직 끝나지 않았을 수 있으므로, negotiate된 프로토콜이
+// 나중에 드러나면 기존 상태 카운터를 이전 버킷에서 새 버킷으로 옮긴다.
 func (c *ConnStateCounter) TrackConnState(conn net.Conn, state http.ConnState) {
 	c.mu.Lock()
-	prevState, existed := c.connStates[conn]
+	prev, existed := c.connStates[conn]
+
+	proto := prev.proto
+	if proto == "" {
+		proto = protoUnknown
+	}
+	if state == http.StateActive {
+		if tlsConn, ok := conn.(*tls.Conn); ok {
+			if negotiated := tlsConn.ConnectionState().NegotiatedProtocol; negotiated == "h2" {
+				proto = "h2"
+			}
+		}
+	}
 
-	// 이전 상태 카운터 감소
 	if existed {
-		c.decrementState(prevState)
+		c.countersFor(prev.proto).add(prev.state, -1)
 	}
 
-	// 새 상태 카운터 증가
 	switch state {
 	case http.StateNew:
-		c.stateNew.Add(1)
 		c.totalAccepted.Add(1)
-		c.connStates[conn] = state
-
-	case http.StateActive:
-		c.stateActive.Add(1)
-		c.connStates[conn] = state
-
-	case http.StateIdle:
-		c.stateIdle.Add(1)
-		c.connStates[conn] = state
-
-	case http.StateHijacked:
-		c.stateHijacked.Add(1)
-		c.connStates[conn] = state
+		fallthrough
+	case http.StateActive, http.StateIdle, http.StateHijacked:
+		c.countersFor(proto).add(state, 1)
+		c.connStates[conn] = connInfo{state: state, proto: proto}
 
 	case http.StateClosed:
 		// Closed 상태는 맵에서 제거 (메모리 누수 방지)
@@ -98,33 +136,38 @@ func (c *ConnStateCounter) TrackConnState(conn net.Conn, state http.ConnState) {
 
 	c.logger.Debug().
 		Str("remote_addr", remoteAddr).
-		Str("prev_state", stateName(prevState)).
+		Str("prev_state", stateName(prev.state)).
 		Str("new_state", stateName(state)).
+		Str("proto", proto).
 		Msg("connection state changed")
 }
 
-// decrementState decreases the counter for the given state.
-// 호출자가 lock을 이미 획득한 상태여야 함.
-func (c *ConnStateCounter) decrementState(state http.ConnState) {
-	switch state {
-	case http.StateNew:
-		c.stateNew.Add(-1)
-	case http.StateActive:
-		c.stateActive.Add(-1)
-	case http.StateIdle:
-		c.stateIdle.Add(-1)
-	case http.StateHijacked:
-		c.stateHijacked.Add(-1)
+// countersFor returns the per-protocol counter bucket for proto.
+func (c *ConnStateCounter) countersFor(proto string) *protoCounters {
+	if proto == "h2" {
+		return &c.h2
 	}
+	return &c.h1
 }
 
-// Snapshot returns the current state of all counters.
+// Snapshot returns the current state of all counters, both aggregate and
+// broken down per ALPN-negotiated protocol.
 type Snapshot struct {
 	New      int64 `json:"new"`
 	Active   int64 `json:"active"`
 	Idle     int64 `json:"idle"`
 	Hijacked int64 `json:"hijacked"`
 
+	NewH1      int64 `json:"new_h1"`
+	ActiveH1   int64 `json:"active_h1"`
+	IdleH1     int64 `json:"idle_h1"`
+	HijackedH1 int64 `json:"hijacked_h1"`
+
+	NewH2      int64 `json:"new_h2"`
+	ActiveH2   int64 `json:"active_h2"`
+	IdleH2     int64 `json:"idle_h2"`
+	HijackedH2 int64 `json:"hijacked_h2"`
+
 	TotalAccepted int64 `json:"total_accepted"`
 	TotalClosed   int64 `json:"total_closed"`
 }
@@ -132,15 +175,65 @@ type Snapshot struct {
 // GetSnapshot returns a point-in-time snapshot of connection states.
 func (c *ConnStateCounter) GetSnapshot() Snapshot {
 	return Snapshot{
-		New:           c.stateNew.Load(),
-		Active:        c.stateActive.Load(),
-		Idle:          c.stateIdle.Load(),
-		Hijacked:      c.stateHijacked.Load(),
+		New:      c.h1.stateNew.Load() + c.h2.stateNew.Load(),
+		Active:   c.h1.stateActive.Load() + c.h2.stateActive.Load(),
+		Idle:     c.h1.stateIdle.Load() + c.h2.stateIdle.Load(),
+		Hijacked: c.h1.stateHijacked.Load() + c.h2.stateHijacked.Load(),
+
+		NewH1:      c.h1.stateNew.Load(),
+		ActiveH1:   c.h1.stateActive.Load(),
+		IdleH1:     c.h1.stateIdle.Load(),
+		HijackedH1: c.h1.stateHijacked.Load(),
+
+		NewH2:      c.h2.stateNew.Load(),
+		ActiveH2:   c.h2.stateActive.Load(),
+		IdleH2:     c.h2.stateIdle.Load(),
+		HijackedH2: c.h2.stateHijacked.Load(),
+
 		TotalAccepted: c.totalAccepted.Load(),
 		TotalClosed:   c.totalClosed.Load(),
 	}
 }
 
+// ActiveConns returns the connections currently tracked as open (any state
+// other than closed). Used during shutdown to forcibly close connections
+// that didn't drain within the deadline.
+func (c *ConnStateCounter) ActiveConns() []net.Conn {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	conns := make([]net.Conn, 0, len(c.connStates))
+	for conn := range c.connStates {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// WriteProm writes the connection state counters in Prometheus text
+// exposition format, without depending on client_golang.
+func (c *ConnStateCounter) WriteProm(w io.Writer) {
+	snap := c.GetSnapshot()
+
+	fmt.Fprintln(w, "# HELP http_conn_state Current number of connections in each http.ConnState, by negotiated protocol.")
+	fmt.Fprintln(w, "# TYPE http_conn_state gauge")
+	fmt.Fprintf(w, "http_conn_state{state=\"new\",proto=\"h1\"} %d\n", snap.NewH1)
+	fmt.Fprintf(w, "http_conn_state{state=\"active\",proto=\"h1\"} %d\n", snap.ActiveH1)
+	fmt.Fprintf(w, "http_conn_state{state=\"idle\",proto=\"h1\"} %d\n", snap.IdleH1)
+	fmt.Fprintf(w, "http_conn_state{state=\"hijacked\",proto=\"h1\"} %d\n", snap.HijackedH1)
+	fmt.Fprintf(w, "http_conn_state{state=\"new\",proto=\"h2\"} %d\n", snap.NewH2)
+	fmt.Fprintf(w, "http_conn_state{state=\"active\",proto=\"h2\"} %d\n", snap.ActiveH2)
+	fmt.Fprintf(w, "http_conn_state{state=\"idle\",proto=\"h2\"} %d\n", snap.IdleH2)
+	fmt.Fprintf(w, "http_conn_state{state=\"hijacked\",proto=\"h2\"} %d\n", snap.HijackedH2)
+
+	fmt.Fprintln(w, "# HELP http_conn_accepted_total Total connections accepted since start.")
+	fmt.Fprintln(w, "# TYPE http_conn_accepted_total counter")
+	fmt.Fprintf(w, "http_conn_accepted_total %d\n", snap.TotalAccepted)
+
+	fmt.Fprintln(w, "# HELP http_conn_closed_total Total connections closed since start.")
+	fmt.Fprintln(w, "# TYPE http_conn_closed_total counter")
+	fmt.Fprintf(w, "http_conn_closed_total %d\n", snap.TotalClosed)
+}
+
 // stateName returns human-readable name for http.ConnState.
 func stateName(state http.ConnState) string {
 	switch state {
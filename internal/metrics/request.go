@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ahwlsqja/go-http-lab/internal/histogram"
+)
+
+// routeStats holds the counters tracked for a single route.
+type routeStats struct {
+	requests atomic.Int64
+	inFlight atomic.Int64
+	bytesOut atomic.Int64
+	latency  histogram.Histogram
+}
+
+// RequestMetrics tracks per-route request counts, in-flight requests,
+// response bytes, and latency so the lab's /metrics endpoint has more to
+// show than just connection state.
+type RequestMetrics struct {
+	routes sync.Map // string (route) -> *routeStats
+}
+
+// NewRequestMetrics creates an empty RequestMetrics tracker.
+func NewRequestMetrics() *RequestMetrics {
+	return &RequestMetrics{}
+}
+
+func (m *RequestMetrics) routeFor(route string) *routeStats {
+	if v, ok := m.routes.Load(route); ok {
+		return v.(*routeStats)
+	}
+	v, _ := m.routes.LoadOrStore(route, &routeStats{})
+	return v.(*routeStats)
+}
+
+// Begin marks the start of a request against route and returns a func that
+// must be called when the request completes, recording bytes written and
+// latency.
+func (m *RequestMetrics) Begin(route string) func(bytesOut int, latency time.Duration) {
+	rs := m.routeFor(route)
+	rs.inFlight.Add(1)
+
+	return func(bytesOut int, latency time.Duration) {
+		rs.inFlight.Add(-1)
+		rs.requests.Add(1)
+		rs.bytesOut.Add(int64(bytesOut))
+		rs.latency.Record(latency)
+	}
+}
+
+// WriteProm writes per-route request metrics in Prometheus text exposition
+// format.
+func (m *RequestMetrics) WriteProm(w io.Writer) {
+	fmt.Fprintln(w, "# HELP http_requests_total Total requests handled per route.")
+	fmt.Fprintln(w, "# TYPE http_requests_total counter")
+	m.routes.Range(func(key, value any) bool {
+		route := key.(string)
+		rs := value.(*routeStats)
+		fmt.Fprintf(w, "http_requests_total{route=%q} %d\n", route, rs.requests.Load())
+		return true
+	})
+
+	fmt.Fprintln(w, "# HELP http_requests_in_flight Requests currently being handled per route.")
+	fmt.Fprintln(w, "# TYPE http_requests_in_flight gauge")
+	m.routes.Range(func(key, value any) bool {
+		route := key.(string)
+		rs := value.(*routeStats)
+		fmt.Fprintf(w, "http_requests_in_flight{route=%q} %d\n", route, rs.inFlight.Load())
+		return true
+	})
+
+	fmt.Fprintln(w, "# HELP http_response_bytes_total Total response bytes written per route.")
+	fmt.Fprintln(w, "# TYPE http_response_bytes_total counter")
+	m.routes.Range(func(key, value any) bool {
+		route := key.(string)
+		rs := value.(*routeStats)
+		fmt.Fprintf(w, "http_response_bytes_total{route=%q} %d\n", route, rs.bytesOut.Load())
+		return true
+	})
+
+	fmt.Fprintln(w, "# HELP http_request_duration_seconds Request latency per route.")
+	fmt.Fprintln(w, "# TYPE http_request_duration_seconds summary")
+	m.routes.Range(func(key, value any) bool {
+		route := key.(string)
+		rs := value.(*routeStats)
+		for _, q := range []float64{0.5, 0.95, 0.99} {
+			seconds := rs.latency.Percentile(q * 100).Seconds()
+			fmt.Fprintf(w, "http_request_duration_seconds{route=%q,quantile=\"%.2f\"} %f\n", route, q, seconds)
+		}
+		fmt.Fprintf(w, "http_request_duration_seconds_count{route=%q} %d\n", route, rs.latency.Count())
+		return true
+	})
+}
@@ -0,0 +1,56 @@
+// Package netx provides net.Listener wrappers for the liveness lab's
+// server-side experiments.
+package netx
+
+import (
+	"net"
+	"sync"
+)
+
+// limitListener wraps a net.Listener so that Accept blocks once n
+// connections are open at once, releasing a slot only when the accepted
+// Conn is closed. Modeled on the semaphore-gated listener pattern used by
+// tylerb/graceful, so slowloris-style experiments can demonstrate
+// backpressure instead of unbounded goroutine growth.
+type limitListener struct {
+	net.Listener
+	sem chan struct{}
+}
+
+// LimitListener returns a Listener that accepts at most n simultaneous
+// connections from the given Listener.
+func LimitListener(l net.Listener, n int) net.Listener {
+	return &limitListener{Listener: l, sem: make(chan struct{}, n)}
+}
+
+// Accept blocks until a slot is free, then delegates to the underlying
+// Listener. The returned Conn releases its slot on Close.
+func (l *limitListener) Accept() (net.Conn, error) {
+	l.sem <- struct{}{}
+
+	c, err := l.Listener.Accept()
+	if err != nil {
+		<-l.sem
+		return nil, err
+	}
+
+	return &limitListenerConn{Conn: c, release: l.release}, nil
+}
+
+func (l *limitListener) release() {
+	<-l.sem
+}
+
+// limitListenerConn releases its listener's slot exactly once, even if
+// Close is called more than once (net/http does this during shutdown).
+type limitListenerConn struct {
+	net.Conn
+	releaseOnce sync.Once
+	release     func()
+}
+
+func (c *limitListenerConn) Close() error {
+	err := c.Conn.Close()
+	c.releaseOnce.Do(c.release)
+	return err
+}
@@ -0,0 +1,145 @@
+// Package rawpool drives HTTP requests over a fixed-size pool of persistent
+// net.Conn objects, bypassing net/http.Client and its Transport entirely.
+//
+// loadgen의 기본 모드는 net/http.Client를 통해 요청을 보내는데, Transport가
+// 커넥션 풀링, 리다이렉트, 프록시 등 여러 부가 기능을 함께 수행하기 때문에
+// 순수한 "연결 하나 당 요청 하나" 오버헤드를 측정하기 어렵다. 이 패키지는
+// 그 오버헤드를 걷어내고 서버의 ConnState 전이를 예측 가능한 연결 수로
+// 유발하기 위한 -mode=raw 전용 경로다.
+package rawpool
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Backoff bounds for Replace's redial retries: start short, cap so a
+// sustained outage doesn't turn into minutes-long waits between attempts.
+const (
+	replaceInitialBackoff = 100 * time.Millisecond
+	replaceMaxBackoff     = 5 * time.Second
+)
+
+// Connection wraps a dialed net.Conn with a buffered reader so
+// http.ReadResponse can parse responses off it directly.
+type Connection struct {
+	Conn   net.Conn
+	Reader *bufio.Reader
+}
+
+// ConnectionManager holds a fixed-size pool of persistent connections.
+// 워커는 Get으로 연결을 꺼내 쓰고, 다 쓰면 Put으로 반납한다. 에러가 나면
+// Put 대신 Replace를 호출해 새 연결을 대신 채워 넣는다.
+type ConnectionManager struct {
+	conns chan *Connection
+	dial  func() (net.Conn, error)
+
+	// ConnectionErrors counts broken connections handed to Replace: one per
+	// call, however many redial attempts that call takes.
+	ConnectionErrors atomic.Int64
+}
+
+// NewConnectionManager dials `size` connections up front and returns a
+// manager backed by a buffered channel of that size.
+func NewConnectionManager(size int, dial func() (net.Conn, error)) (*ConnectionManager, error) {
+	m := &ConnectionManager{
+		conns: make(chan *Connection, size),
+		dial:  dial,
+	}
+
+	for i := 0; i < size; i++ {
+		c, err := m.newConnection()
+		if err != nil {
+			return nil, err
+		}
+		m.conns <- c
+	}
+
+	return m, nil
+}
+
+func (m *ConnectionManager) newConnection() (*Connection, error) {
+	conn, err := m.dial()
+	if err != nil {
+		return nil, err
+	}
+	return &Connection{Conn: conn, Reader: bufio.NewReader(conn)}, nil
+}
+
+// Get checks out a connection from the pool, blocking until one is available.
+func (m *ConnectionManager) Get() *Connection {
+	return <-m.conns
+}
+
+// Put returns a healthy connection to the pool.
+func (m *ConnectionManager) Put(c *Connection) {
+	m.conns <- c
+}
+
+// Replace closes a broken connection and keeps retrying a redial, with a
+// capped exponential backoff, until one succeeds or done fires. A failed
+// redial must never leave the pool's slot unfilled - that would let a
+// sustained outage monotonically shrink the pool's effective concurrency
+// instead of just slowing it down. ConnectionErrors is incremented once
+// regardless of how many redial attempts it takes, since the checked-out
+// connection was still unusable.
+func (m *ConnectionManager) Replace(broken *Connection, done <-chan struct{}) {
+	if broken != nil && broken.Conn != nil {
+		broken.Conn.Close()
+	}
+	m.ConnectionErrors.Add(1)
+
+	backoff := replaceInitialBackoff
+	for {
+		c, err := m.newConnection()
+		if err == nil {
+			m.conns <- c
+			return
+		}
+
+		select {
+		case <-done:
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > replaceMaxBackoff {
+			backoff = replaceMaxBackoff
+		}
+	}
+}
+
+// Close drains the pool and closes every connection. Only safe to call
+// once no worker holds a checked-out connection.
+func (m *ConnectionManager) Close() {
+	for {
+		select {
+		case c := <-m.conns:
+			c.Conn.Close()
+		default:
+			return
+		}
+	}
+}
+
+// Do serializes req directly onto c's connection (bypassing Transport)
+// and parses the response off c's buffered reader.
+func Do(c *Connection, req *http.Request, timeout time.Duration) (*http.Response, error) {
+	if timeout > 0 {
+		c.Conn.SetDeadline(time.Now().Add(timeout))
+	}
+
+	if err := req.Write(c.Conn); err != nil {
+		return nil, err
+	}
+
+	resp, err := http.ReadResponse(c.Reader, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
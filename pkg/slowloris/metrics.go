@@ -0,0 +1,142 @@
+package slowloris
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Snapshot is a point-in-time read of Stats, taken by the stats reporter on
+// every report-interval tick and handed to each configured StatsSink.
+type Snapshot struct {
+	Time           time.Time `json:"time"`
+	ActiveConns    int64     `json:"active_conns"`
+	TotalConns     int64     `json:"total_conns"`
+	ClosedByServer int64     `json:"closed_by_server"`
+	Errors         int64     `json:"errors"`
+	TLSErrors      int64     `json:"tls_errors"`
+	HeadersSent    int64     `json:"headers_sent"`
+	BodyBytesSent  int64     `json:"body_bytes_sent"`
+}
+
+// StatsSink receives a Snapshot on every stats-reporter tick. Several sinks
+// can be active at once - the zerolog sink is always on, -metrics-file and
+// -metrics-addr each add one more.
+type StatsSink interface {
+	Report(Snapshot)
+}
+
+// zerologSink logs each snapshot as a structured line - the original,
+// hardcoded stats-reporter behavior before sinks were pluggable.
+type zerologSink struct {
+	log zerolog.Logger
+}
+
+func (s zerologSink) Report(snap Snapshot) {
+	s.log.Info().
+		Int64("active_conns", snap.ActiveConns).
+		Int64("total_conns", snap.TotalConns).
+		Int64("closed_by_server", snap.ClosedByServer).
+		Int64("errors", snap.Errors).
+		Int64("tls_errors", snap.TLSErrors).
+		Int64("headers_sent", snap.HeadersSent).
+		Int64("body_bytes_sent", snap.BodyBytesSent).
+		Msg("stats")
+}
+
+// jsonlSink appends one JSON object per line to a file, for offline
+// analysis or for a log pipeline to pick up.
+type jsonlSink struct {
+	mu  sync.Mutex
+	f   *os.File
+	log zerolog.Logger
+}
+
+// newJSONLSink opens path for appending (creating it if needed) and
+// returns a sink that writes one Snapshot per line to it.
+func newJSONLSink(path string, log zerolog.Logger) (*jsonlSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonlSink{f: f, log: log}, nil
+}
+
+func (s *jsonlSink) Report(snap Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := json.NewEncoder(s.f).Encode(snap); err != nil {
+		s.log.Debug().Err(err).Msg("failed to write metrics jsonl line")
+	}
+}
+
+// Close flushes and closes the underlying file. Safe to call once, after
+// the stats reporter has stopped.
+func (s *jsonlSink) Close() error {
+	return s.f.Close()
+}
+
+// promSink keeps the latest snapshot around so the /metrics HTTP handler
+// can serve it on scrape - Prometheus's usual pull model - instead of
+// pushing on every report-interval tick.
+type promSink struct {
+	latest atomic.Pointer[Snapshot]
+	stats  *Stats
+}
+
+func newPromSink(stats *Stats) *promSink {
+	return &promSink{stats: stats}
+}
+
+func (s *promSink) Report(snap Snapshot) {
+	s.latest.Store(&snap)
+}
+
+// Handler returns the http.Handler that serves the latest snapshot plus
+// the per-connection lifetime histogram in Prometheus text exposition
+// format.
+func (s *promSink) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		snap := s.latest.Load()
+		if snap == nil {
+			snap = &Snapshot{}
+		}
+
+		fmt.Fprintln(w, "# HELP slowloris_active_conns Connections currently held open.")
+		fmt.Fprintln(w, "# TYPE slowloris_active_conns gauge")
+		fmt.Fprintf(w, "slowloris_active_conns %d\n", snap.ActiveConns)
+
+		fmt.Fprintln(w, "# HELP slowloris_total_conns Total connections attempted.")
+		fmt.Fprintln(w, "# TYPE slowloris_total_conns counter")
+		fmt.Fprintf(w, "slowloris_total_conns %d\n", snap.TotalConns)
+
+		fmt.Fprintln(w, "# HELP slowloris_closed_by_server_total Connections the server closed (e.g. ReadHeaderTimeout).")
+		fmt.Fprintln(w, "# TYPE slowloris_closed_by_server_total counter")
+		fmt.Fprintf(w, "slowloris_closed_by_server_total %d\n", snap.ClosedByServer)
+
+		fmt.Fprintln(w, "# HELP slowloris_errors_total Connection errors (TCP dial failures).")
+		fmt.Fprintln(w, "# TYPE slowloris_errors_total counter")
+		fmt.Fprintf(w, "slowloris_errors_total %d\n", snap.Errors)
+
+		fmt.Fprintln(w, "# HELP slowloris_tls_errors_total TLS handshake failures (TCP dial succeeded).")
+		fmt.Fprintln(w, "# TYPE slowloris_tls_errors_total counter")
+		fmt.Fprintf(w, "slowloris_tls_errors_total %d\n", snap.TLSErrors)
+
+		fmt.Fprintln(w, "# HELP slowloris_conn_lifetime_seconds Per-connection lifetime, dial to close.")
+		fmt.Fprintln(w, "# TYPE slowloris_conn_lifetime_seconds summary")
+		for _, q := range []float64{0.5, 0.95, 0.99} {
+			seconds := s.stats.connLifetime.Percentile(q * 100).Seconds()
+			fmt.Fprintf(w, "slowloris_conn_lifetime_seconds{quantile=\"%.2f\"} %f\n", q, seconds)
+		}
+		fmt.Fprintf(w, "slowloris_conn_lifetime_seconds_count %d\n", s.stats.connLifetime.Count())
+	})
+}
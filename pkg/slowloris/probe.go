@@ -0,0 +1,122 @@
+package slowloris
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/ahwlsqja/go-http-lab/internal/logger"
+)
+
+// ProbeResult is the structured outcome of a -probe run, suitable for CI
+// pipelines to assert against (e.g. "this server must stay protected").
+type ProbeResult struct {
+	Target     string        `json:"target"`
+	CloseGap   time.Duration `json:"close_gap"`
+	Threshold  time.Duration `json:"threshold"`
+	Vulnerable bool          `json:"vulnerable"`
+	ErrorA     string        `json:"error_a,omitempty"`
+	ErrorB     string        `json:"error_b,omitempty"`
+}
+
+// RunProbe performs the Qualys-style non-destructive slowloris check: two
+// connections (A, B) send identical partial headers, then B alone gets one
+// extra header line after a wait. If the server closes B much later than A,
+// it's extending B's read deadline on trickled bytes - the slowloris
+// vulnerability signature - without ever flooding the target. Unlike the
+// flood-mode Execute, RunProbe only reports the result; callers (the CLI,
+// or a test) decide how to present it.
+func RunProbe(cfg Config) (ProbeResult, error) {
+	log := logger.New(cfg.Debug)
+	log.Info().Str("target", cfg.Target).Dur("wait", cfg.ProbeWait).Msg("starting non-destructive probe")
+
+	connA, err := net.DialTimeout("tcp", cfg.Target, 10*time.Second)
+	if err != nil {
+		return ProbeResult{}, fmt.Errorf("dialing connection A: %w", err)
+	}
+	defer connA.Close()
+
+	connB, err := net.DialTimeout("tcp", cfg.Target, 10*time.Second)
+	if err != nil {
+		return ProbeResult{}, fmt.Errorf("dialing connection B: %w", err)
+	}
+	defer connB.Close()
+
+	header := fmt.Sprintf("GET /?probe=1 HTTP/1.1\r\nHost: %s\r\nUser-Agent: slowloris-go/1.0-probe\r\n", cfg.Target)
+	if _, err := connA.Write([]byte(header)); err != nil {
+		return ProbeResult{}, fmt.Errorf("writing headers to A: %w", err)
+	}
+	if _, err := connB.Write([]byte(header)); err != nil {
+		return ProbeResult{}, fmt.Errorf("writing headers to B: %w", err)
+	}
+
+	time.Sleep(cfg.ProbeWait)
+
+	// B만 헤더를 하나 더 받는다 - 서버가 바이트 하나로도 read deadline을
+	// 연장해주는지 (취약점 신호) 보기 위함.
+	if _, err := connB.Write([]byte("X-Probe-Extra: 1\r\n")); err != nil {
+		return ProbeResult{}, fmt.Errorf("writing extra header to B: %w", err)
+	}
+
+	resA := make(chan closeResult, 1)
+	resB := make(chan closeResult, 1)
+	go func() { resA <- waitForClose(connA, cfg.ProbeTimeout) }()
+	go func() { resB <- waitForClose(connB, cfg.ProbeTimeout) }()
+
+	a := <-resA
+	b := <-resB
+
+	gap := b.closedAt.Sub(a.closedAt)
+	vulnerable := gap > cfg.ProbeThreshold
+	if !a.peerClosed && !b.peerClosed {
+		// 둘 다 피어가 끊지 않고 우리 쪽 ProbeTimeout으로 끝났다 - 즉
+		// read/header timeout이 전혀 없다는 뜻이다. 이 경우 A/B closedAt이
+		// 거의 동시라 gap이 작게 나와 PROTECTED로 새기 쉽지만, 이건 gap
+		// 기반 신호보다 더 명백한 취약점이므로 강제로 취약 판정한다.
+		vulnerable = true
+	}
+
+	result := ProbeResult{
+		Target:     cfg.Target,
+		CloseGap:   gap,
+		Threshold:  cfg.ProbeThreshold,
+		Vulnerable: vulnerable,
+	}
+	if a.err != nil {
+		result.ErrorA = a.err.Error()
+	}
+	if b.err != nil {
+		result.ErrorB = b.err.Error()
+	}
+
+	return result, nil
+}
+
+// closeResult records when a probe connection's read loop ended, why, and
+// whether the peer actually closed it (as opposed to our own ProbeTimeout
+// firing locally because the peer never responded at all).
+type closeResult struct {
+	closedAt   time.Time
+	peerClosed bool
+	err        error
+}
+
+// waitForClose blocks reading conn until it's closed by the peer or the
+// given cap elapses, whichever comes first. peerClosed distinguishes a
+// genuine peer close (EOF/reset) from our own read deadline expiring
+// because the peer never closed the connection at all.
+func waitForClose(conn net.Conn, cap time.Duration) closeResult {
+	deadline := time.Now().Add(cap)
+	conn.SetReadDeadline(deadline)
+
+	buf := make([]byte, 1)
+	_, err := conn.Read(buf)
+	now := time.Now()
+
+	peerClosed := true
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		peerClosed = false
+	}
+
+	return closeResult{closedAt: now, peerClosed: peerClosed, err: err}
+}
